@@ -0,0 +1,49 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PeerAuthorizer decides whether an authenticated peer may invoke a given
+// ClusterService RPC. It is consulted by PeerAuthUnaryInterceptor for every
+// request once mTLS is enabled on the server.
+type PeerAuthorizer interface {
+	// Authorize returns an error (typically a gRPC status with codes.PermissionDenied)
+	// if identity is not allowed to call fullMethod.
+	Authorize(identity PeerIdentity, fullMethod string) error
+}
+
+// PeerAuthUnaryInterceptor builds a grpc.UnaryServerInterceptor that extracts
+// the caller's PeerIdentity from its verified mTLS certificate and checks it
+// against authz before invoking the handler. Use NewServer to build a
+// ClusterService gRPC server with this interceptor and mTLS already wired
+// in, so Apply, Query and JoinPeer can authorize the caller instead of
+// trusting network reachability alone. Requests over an insecure (non-TLS)
+// connection are rejected.
+func PeerAuthUnaryInterceptor(authz PeerAuthorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity, ok := PeerIdentityFromContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "cluster transport: no verified peer certificate")
+		}
+		if err := authz.Authorize(identity, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
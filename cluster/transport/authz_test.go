@@ -0,0 +1,87 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type stubAuthorizer struct {
+	err error
+}
+
+func (s stubAuthorizer) Authorize(PeerIdentity, string) error { return s.err }
+
+func verifiedPeerContext(commonName string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+}
+
+func TestPeerAuthUnaryInterceptorRejectsUnauthenticated(t *testing.T) {
+	interceptor := PeerAuthUnaryInterceptor(stubAuthorizer{})
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/ClusterService/Apply"}, handler)
+
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+	assert.False(t, called)
+}
+
+func TestPeerAuthUnaryInterceptorRejectsWhenAuthorizeFails(t *testing.T) {
+	denyErr := status.Error(codes.PermissionDenied, "not allowed")
+	interceptor := PeerAuthUnaryInterceptor(stubAuthorizer{err: denyErr})
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+
+	_, err := interceptor(verifiedPeerContext("node-1"), nil, &grpc.UnaryServerInfo{FullMethod: "/ClusterService/Apply"}, handler)
+
+	assert.Equal(t, denyErr, err)
+	assert.False(t, called)
+}
+
+func TestPeerAuthUnaryInterceptorCallsHandlerWhenAuthorized(t *testing.T) {
+	interceptor := PeerAuthUnaryInterceptor(stubAuthorizer{})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	resp, err := interceptor(verifiedPeerContext("node-1"), nil, &grpc.UnaryServerInfo{FullMethod: "/ClusterService/Apply"}, handler)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
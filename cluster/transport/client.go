@@ -17,10 +17,11 @@ import (
 	"net"
 	"strconv"
 	"sync"
+	"time"
 
 	cmd "github.com/weaviate/weaviate/cluster/proto/api"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
 )
 
 const serviceConfig = `
@@ -60,114 +61,269 @@ type rpcAddressResolver interface {
 
 // Client is used for communication with remote nodes in a RAFT cluster.
 type Client struct {
-	rpc rpcAddressResolver
+	rpc   rpcAddressResolver
+	creds TransportCredentialsProvider
+	dopts []grpc.DialOption
 
-	connLock   sync.Mutex
-	leaderAddr string
-	leaderConn *grpc.ClientConn
+	pool    *connPool
+	metrics *Metrics
+
+	idleTTL   time.Duration
+	evictStop chan struct{}
+
+	leaderMu     sync.RWMutex
+	cachedLeader string
+}
+
+// ClientOption configures optional behavior of Client, set with NewClientWithOptions.
+type ClientOption func(*Client)
+
+// WithTransportCredentials configures the credential provider used to dial
+// peers, enabling mTLS between RAFT nodes. Without this option, Client dials
+// peers using insecure.NewCredentials(), preserving the historical behavior.
+func WithTransportCredentials(p TransportCredentialsProvider) ClientOption {
+	return func(cl *Client) { cl.creds = p }
+}
+
+// WithDialOptions appends extra grpc.DialOption to every dial made by Client,
+// e.g. grpc.WithChainUnaryInterceptor for metrics or tracing.
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(cl *Client) { cl.dopts = append(cl.dopts, opts...) }
+}
+
+// WithIdleConnTTL enables periodic eviction of pooled connections that have
+// had no outstanding Get without a matching Release for at least ttl,
+// reclaiming resources tied up by peers this Client no longer talks to
+// (e.g. a removed node, or a follower left behind by a stale leader cache).
+// Idle eviction is disabled unless this option is set.
+func WithIdleConnTTL(ttl time.Duration) ClientOption {
+	return func(cl *Client) { cl.idleTTL = ttl }
 }
 
 func NewClient(r rpcAddressResolver) *Client {
-	return &Client{rpc: r}
+	return NewClientWithOptions(r)
 }
 
-// Join joins this node to an existing cluster identified by its leader's address.
-// If a new leader has been elected, the request is redirected to the new leader.
-func (cl *Client) Join(ctx context.Context, leaderAddress string, req *cmd.JoinPeerRequest) (*cmd.JoinPeerResponse, error) {
-	conn, err := cl.getConn(leaderAddress)
-	if err != nil {
-		return nil, err
+// NewClientWithOptions builds a Client with optional credentials and dial
+// options. Use this instead of NewClient to run the RAFT cluster transport
+// over mTLS, to attach interceptors, or to enable idle connection eviction.
+func NewClientWithOptions(r rpcAddressResolver, opts ...ClientOption) *Client {
+	cl := &Client{rpc: r, creds: InsecureCredentialsProvider{}}
+	for _, opt := range opts {
+		opt(cl)
 	}
-	c := cmd.NewClusterServiceClient(conn)
-	return c.JoinPeer(ctx, req)
+
+	dial := cl.dial
+	if cl.metrics != nil {
+		dial = func(addr string) (*grpc.ClientConn, error) {
+			return cl.metrics.instrumentedDial(addr, cl.dial)
+		}
+	}
+	cl.pool = newConnPool(dial)
+	cl.startIdleEviction()
+	return cl
 }
 
-// Notify informs a remote node rAddr of this node's readiness to join.
-func (cl *Client) Notify(ctx context.Context, rAddr string, req *cmd.NotifyPeerRequest) (*cmd.NotifyPeerResponse, error) {
-	addr, err := cl.rpc.Address(rAddr)
-	if err != nil {
-		return nil, fmt.Errorf("resolve address: %w", err)
+// startIdleEviction begins periodically reclaiming connections that have
+// sat idle for longer than cl.idleTTL. It is a no-op unless WithIdleConnTTL
+// was set. The eviction goroutine is stopped by CloseAll.
+func (cl *Client) startIdleEviction() {
+	if cl.idleTTL <= 0 {
+		return
+	}
+	interval := cl.idleTTL / 2
+	if interval < time.Second {
+		interval = time.Second
 	}
 
-	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cl.evictStop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cl.pool.EvictIdle(cl.idleTTL)
+			case <-cl.evictStop:
+				return
+			}
+		}
+	}()
+}
+
+// dialOptions returns the base grpc.DialOption slice for dialing addr,
+// combining the configured TransportCredentialsProvider with any extra
+// dial options.
+func (cl *Client) dialOptions(addr string) ([]grpc.DialOption, error) {
+	creds, err := cl.creds.TransportCredentials(addr)
 	if err != nil {
-		return nil, fmt.Errorf("dial: %w", err)
+		return nil, fmt.Errorf("resolve transport credentials for %q: %w", addr, err)
 	}
-	defer conn.Close()
-	c := cmd.NewClusterServiceClient(conn)
-	return c.NotifyPeer(ctx, req)
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, cl.dopts...)
+	return opts, nil
 }
 
-// Remove removes this node from an existing cluster identified by its leader's address.
-// If a new leader has been elected, the request is redirected to the new leader.
-func (cl *Client) Remove(ctx context.Context, leaderAddress string, req *cmd.RemovePeerRequest) (*cmd.RemovePeerResponse, error) {
-	conn, err := cl.getConn(leaderAddress)
+// dial opens a new, persistent connection to addr. It is used by the
+// connPool as the lazy-creation function for each peer.
+func (cl *Client) dial(addr string) (*grpc.ClientConn, error) {
+	opts, err := cl.dialOptions(addr)
 	if err != nil {
 		return nil, err
 	}
-	c := cmd.NewClusterServiceClient(conn)
-	return c.RemovePeer(ctx, req)
+	opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
+	return grpc.Dial(addr, opts...)
 }
 
-func (cl *Client) Apply(leaderAddr string, req *cmd.ApplyRequest) (*cmd.ApplyResponse, error) {
-	ctx := context.Background()
-	conn, err := cl.getConn(leaderAddr)
+// Join joins this node to an existing cluster identified by any known peer
+// address: it need not already be the leader. If the contacted peer isn't
+// the leader, the request is transparently redirected using its NotLeader
+// hint and the resolved leader is cached for subsequent calls. Join forwards
+// req as-is; populating it with this node's advertised {raftAddr, rpcAddr,
+// apiAddr, version} so the leader can Apply an AdvertisePeer command (see
+// PeerRegistry) is the caller's responsibility, since only the Raft
+// store/FSM package constructing req knows that command's real shape.
+func (cl *Client) Join(ctx context.Context, peerAddress string, req *cmd.JoinPeerRequest) (*cmd.JoinPeerResponse, error) {
+	var resp *cmd.JoinPeerResponse
+	err := withLeaderRedirect(cl, "Join", peerAddress, func(addr string) error {
+		conn, release, err := cl.getConn(addr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := cmd.NewClusterServiceClient(conn)
+		resp, err = c.JoinPeer(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Notify informs a remote node rAddr of this node's readiness to join.
+func (cl *Client) Notify(ctx context.Context, rAddr string, req *cmd.NotifyPeerRequest) (*cmd.NotifyPeerResponse, error) {
+	conn, release, err := cl.getConn(rAddr)
 	if err != nil {
 		return nil, err
 	}
-
+	defer release()
 	c := cmd.NewClusterServiceClient(conn)
-	return c.Apply(ctx, req)
+	return c.NotifyPeer(ctx, req)
 }
 
-func (cl *Client) Query(ctx context.Context, leaderAddress string, req *cmd.QueryRequest) (*cmd.QueryResponse, error) {
-	conn, err := cl.getConn(leaderAddress)
-	if err != nil {
-		return nil, err
+// Remove removes this node from an existing cluster identified by any known
+// peer address. If the contacted peer isn't the leader, the request is
+// transparently redirected using its NotLeader hint.
+func (cl *Client) Remove(ctx context.Context, peerAddress string, req *cmd.RemovePeerRequest) (*cmd.RemovePeerResponse, error) {
+	var resp *cmd.RemovePeerResponse
+	err := withLeaderRedirect(cl, "Remove", peerAddress, func(addr string) error {
+		conn, release, err := cl.getConn(addr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := cmd.NewClusterServiceClient(conn)
+		resp, err = c.RemovePeer(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Apply submits req to be committed through RAFT consensus, starting at
+// peerAddress (or the last cached leader, if known) and transparently
+// redirecting to the leader if peerAddress is a follower.
+func (cl *Client) Apply(peerAddr string, req *cmd.ApplyRequest) (*cmd.ApplyResponse, error) {
+	ctx := context.Background()
+	if cl.metrics != nil {
+		defer cl.metrics.observeDuration(cl.metrics.applyDuration, peerAddr, time.Now())
 	}
 
-	c := cmd.NewClusterServiceClient(conn)
-	return c.Query(ctx, req)
+	var resp *cmd.ApplyResponse
+	err := withLeaderRedirect(cl, "Apply", peerAddr, func(addr string) error {
+		conn, release, err := cl.getConn(addr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := cmd.NewClusterServiceClient(conn)
+		resp, err = c.Apply(ctx, req)
+		return err
+	})
+	return resp, err
 }
 
-func (cl *Client) Close() {
-	if cl.leaderConn != nil {
-		cl.leaderConn.Close()
+// Query runs a consistent read through RAFT, starting at peerAddress (or the
+// last cached leader, if known) and transparently redirecting to the leader
+// if peerAddress is a follower.
+func (cl *Client) Query(ctx context.Context, peerAddress string, req *cmd.QueryRequest) (*cmd.QueryResponse, error) {
+	if cl.metrics != nil {
+		defer cl.metrics.observeDuration(cl.metrics.queryDuration, peerAddress, time.Now())
 	}
+
+	var resp *cmd.QueryResponse
+	err := withLeaderRedirect(cl, "Query", peerAddress, func(addr string) error {
+		conn, release, err := cl.getConn(addr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := cmd.NewClusterServiceClient(conn)
+		resp, err = c.Query(ctx, req)
+		return err
+	})
+	return resp, err
 }
 
-func (cl *Client) getConn(leaderAddress string) (*grpc.ClientConn, error) {
-	cl.connLock.Lock()
-	defer cl.connLock.Unlock()
+// Close tears down the pooled connection to addr, e.g. after a peer is
+// removed from the cluster.
+func (cl *Client) Close(addr string) error {
+	return cl.pool.Close(addr)
+}
 
-	if cl.leaderConn != nil && leaderAddress == cl.leaderAddr {
-		return cl.leaderConn, nil
+// CloseAll tears down every pooled connection held by this Client and stops
+// its idle-eviction goroutine, if WithIdleConnTTL was set.
+func (cl *Client) CloseAll() error {
+	if cl.evictStop != nil {
+		close(cl.evictStop)
 	}
+	return cl.pool.CloseAll()
+}
 
-	if cl.leaderConn != nil {
-		// close open conn if leader addr changed
-		cl.leaderConn.Close()
+// getConn returns a warm, persistent connection to peerAddress, dialing and
+// pooling it on first use, along with a release func the caller must invoke
+// once it is done issuing RPCs on the connection (typically via defer). This
+// lets EvictIdle tell a genuinely idle connection apart from one that is
+// still in use. peerAddress need not be the current leader: the pool keys
+// connections per peer so repeated calls against a flapping leader reuse
+// connections instead of tearing down and redialing on every change.
+func (cl *Client) getConn(peerAddress string) (*grpc.ClientConn, func(), error) {
+	addr, err := cl.rpc.Address(peerAddress)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("resolve address: %w", err)
 	}
 
-	addr, err := cl.rpc.Address(leaderAddress)
+	conn, err := cl.pool.Get(addr)
 	if err != nil {
-		return nil, fmt.Errorf("resolve address: %w", err)
+		return nil, func() {}, err
 	}
+	return conn, func() { cl.pool.Release(addr) }, nil
+}
 
-	cl.leaderConn, err = grpc.Dial(
-		addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultServiceConfig(serviceConfig),
-	)
+// ConnState reports the connectivity.State of the pooled connection to
+// peerAddress, for health/observability dashboards. It returns false if no
+// connection to peerAddress has been established yet.
+func (cl *Client) ConnState(peerAddress string) (connectivity.State, bool) {
+	addr, err := cl.rpc.Address(peerAddress)
 	if err != nil {
-		return nil, fmt.Errorf("dial: %w", err)
+		return connectivity.Idle, false
 	}
-
-	cl.leaderAddr = leaderAddress
-
-	return cl.leaderConn, nil
+	return cl.pool.State(addr)
 }
 
+// NewRPCResolver builds a resolver that derives a peer's RPC address from
+// its Raft address by port arithmetic.
+//
+// Deprecated: this heuristic breaks down in Kubernetes/NAT/mixed-port
+// deployments, where the RPC address can't be derived from the Raft address
+// at all. Prefer NewPeerRegistry, which reads each peer's self-advertised
+// address from Raft-replicated state instead of guessing it.
 func NewRPCResolver(isLocalHost bool, rpcPort int) rpcAddressResolver {
 	return &rpcResolver{isLocalCluster: isLocalHost, rpcPort: rpcPort}
 }
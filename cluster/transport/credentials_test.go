@@ -0,0 +1,140 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestInsecureCredentialsProviderReturnsInsecureCredentials(t *testing.T) {
+	creds, err := InsecureCredentialsProvider{}.TransportCredentials("node-1:9300")
+	require.NoError(t, err)
+	assert.Equal(t, "insecure", creds.Info().SecurityProtocol)
+}
+
+func TestNewTLSCredentialsProviderMissingCertFile(t *testing.T) {
+	dir := t.TempDir()
+	_, caFile := writeTestCA(t, dir)
+
+	_, err := NewTLSCredentialsProvider(TLSCredentialsConfig{
+		CertFile:     filepath.Join(dir, "does-not-exist.crt"),
+		KeyFile:      filepath.Join(dir, "does-not-exist.key"),
+		ClientCAFile: caFile,
+	})
+	assert.ErrorContains(t, err, "load key pair")
+}
+
+func TestNewTLSCredentialsProviderMissingCAFile(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	_, err := NewTLSCredentialsProvider(TLSCredentialsConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: filepath.Join(dir, "does-not-exist.pem"),
+	})
+	assert.ErrorContains(t, err, "read ca bundle")
+}
+
+func TestNewTLSCredentialsProviderInvalidCABundle(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	garbageCA := filepath.Join(dir, "garbage.pem")
+	writeFile(t, garbageCA, []byte("not a certificate"))
+
+	_, err := NewTLSCredentialsProvider(TLSCredentialsConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: garbageCA,
+	})
+	assert.ErrorContains(t, err, "no certificates found")
+}
+
+func TestNewTLSCredentialsProviderSucceeds(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	_, caFile := writeTestCA(t, dir)
+
+	p, err := NewTLSCredentialsProvider(TLSCredentialsConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+	})
+	require.NoError(t, err)
+
+	creds, err := p.TransportCredentials("node-1:9300")
+	require.NoError(t, err)
+	assert.Equal(t, "tls", creds.Info().SecurityProtocol)
+}
+
+func TestPeerIdentityFromContextNoPeer(t *testing.T) {
+	_, ok := PeerIdentityFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestPeerIdentityFromContextNilAuthInfo(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: nil})
+	_, ok := PeerIdentityFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func TestPeerIdentityFromContextNoVerifiedChains(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{}},
+	})
+	_, ok := PeerIdentityFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func TestPeerIdentityFromContextExtractsURISANAndCommonName(t *testing.T) {
+	uri, err := url.Parse("spiffe://cluster.local/ns/raft/node-1")
+	require.NoError(t, err)
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "node-1"},
+		URIs:    []*url.URL{uri},
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+
+	identity, ok := PeerIdentityFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "spiffe://cluster.local/ns/raft/node-1", identity.URISAN)
+	assert.Equal(t, "node-1", identity.CommonName)
+}
+
+func TestPeerIdentityFromContextFallsBackToCommonNameWithoutURISAN(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "node-2"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+
+	identity, ok := PeerIdentityFromContext(ctx)
+	require.True(t, ok)
+	assert.Empty(t, identity.URISAN)
+	assert.Equal(t, "node-2", identity.CommonName)
+}
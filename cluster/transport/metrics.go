@@ -0,0 +1,141 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Metrics holds the Prometheus collectors exported by a Client built with
+// NewClientWithMetrics, so tail latency, retries and connection churn can be
+// diagnosed in production Raft deployments.
+type Metrics struct {
+	applyDuration *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	retriesTotal  *prometheus.CounterVec
+	leaderChanges *prometheus.CounterVec
+	connDials     *prometheus.CounterVec
+	connErrors    *prometheus.CounterVec
+}
+
+// NewMetrics registers the cluster transport's collectors with reg and
+// returns them. The dial counters are labeled by "target" as the dialed RPC
+// address; the Apply/Query duration histograms are labeled by "target" as
+// the peer address the caller passed in, not the leader a redirect reached.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		applyDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "weaviate",
+			Subsystem: "cluster_transport",
+			Name:      "apply_duration_seconds",
+			Help:      "Duration of Client.Apply calls, including any leader redirects.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"target"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "weaviate",
+			Subsystem: "cluster_transport",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of Client.Query calls, including any leader redirects.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"target"}),
+		retriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "weaviate",
+			Subsystem: "cluster_transport",
+			Name:      "retries_total",
+			Help:      "Number of leader-redirect retries performed by the cluster transport client.",
+		}, []string{"rpc"}),
+		leaderChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "weaviate",
+			Subsystem: "cluster_transport",
+			Name:      "leader_changes_total",
+			Help:      "Number of times the client observed a new cached leader address.",
+		}, []string{}),
+		connDials: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "weaviate",
+			Subsystem: "cluster_transport",
+			Name:      "conn_dials_total",
+			Help:      "Number of gRPC dial attempts made by the connection pool.",
+		}, []string{"target"}),
+		connErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "weaviate",
+			Subsystem: "cluster_transport",
+			Name:      "conn_errors_total",
+			Help:      "Number of gRPC dial attempts that failed.",
+		}, []string{"target"}),
+	}
+
+	reg.MustRegister(m.applyDuration, m.queryDuration, m.retriesTotal, m.leaderChanges, m.connDials, m.connErrors)
+	return m
+}
+
+// instrumentedDial wraps dial to record conn_dials_total/conn_errors_total
+// before delegating, e.g. used as the connPool's dial func.
+func (m *Metrics) instrumentedDial(addr string, dial func(string) (*grpc.ClientConn, error)) (*grpc.ClientConn, error) {
+	m.connDials.WithLabelValues(addr).Inc()
+	conn, err := dial(addr)
+	if err != nil {
+		m.connErrors.WithLabelValues(addr).Inc()
+	}
+	return conn, err
+}
+
+// observeDuration records the elapsed time since start against the
+// apply_duration_seconds or query_duration_seconds histogram.
+func (m *Metrics) observeDuration(h *prometheus.HistogramVec, target string, start time.Time) {
+	h.WithLabelValues(target).Observe(time.Since(start).Seconds())
+}
+
+// WithMetrics configures a Client to register Prometheus collectors against
+// reg and trace every RPC with tp, instrumenting dials and leader redirects
+// in the same connPool that NewClientWithOptions builds rather than
+// replacing it afterward, so options like WithIdleConnTTL keep working
+// together with metrics.
+func WithMetrics(reg prometheus.Registerer, tp trace.TracerProvider) ClientOption {
+	return func(cl *Client) {
+		cl.metrics = NewMetrics(reg)
+		cl.dopts = append(cl.dopts, grpc.WithChainUnaryInterceptor(tracingUnaryInterceptor(tp)))
+	}
+}
+
+// NewClientWithMetrics builds a Client instrumented with Prometheus metrics
+// registered against reg and an OpenTelemetry tracing interceptor backed by
+// tp. Query's spans are children of any span in its ctx; Apply starts from
+// context.Background(), so its spans are new root spans, and neither is
+// linked to spans on the receiving node. Additional opts (e.g.
+// WithIdleConnTTL) are applied alongside WithMetrics.
+func NewClientWithMetrics(r rpcAddressResolver, reg prometheus.Registerer, tp trace.TracerProvider, opts ...ClientOption) *Client {
+	allOpts := append([]ClientOption{WithMetrics(reg, tp)}, opts...)
+	return NewClientWithOptions(r, allOpts...)
+}
+
+// tracingUnaryInterceptor starts a client-side span named after the RPC's
+// full method for every unary call and records any error on it. The span's
+// context is not injected into the outgoing gRPC metadata, so the server
+// handling the call does not join the trace.
+func tracingUnaryInterceptor(tp trace.TracerProvider) grpc.UnaryClientInterceptor {
+	tracer := tp.Tracer("github.com/weaviate/weaviate/cluster/transport")
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracer.Start(ctx, method)
+		defer span.End()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
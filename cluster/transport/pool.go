@@ -0,0 +1,189 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connPool is a reference-counted, per-peer pool of gRPC connections. It
+// replaces redialing on every leader change with persistent, warm HTTP/2
+// connections that are reused across Apply/Query/Notify/Join/Remove calls
+// regardless of which peer currently holds the lease on the connection.
+type connPool struct {
+	dial func(addr string) (*grpc.ClientConn, error)
+
+	mu    sync.Mutex
+	conns map[string]*pooledConn
+}
+
+type pooledConn struct {
+	conn     *grpc.ClientConn
+	refCount int
+	// idleSince is the time refCount last dropped to zero. It is the zero
+	// Time while refCount > 0 (the connection is in active use).
+	idleSince time.Time
+}
+
+func newConnPool(dial func(addr string) (*grpc.ClientConn, error)) *connPool {
+	return &connPool{
+		dial:  dial,
+		conns: make(map[string]*pooledConn),
+	}
+}
+
+// Get returns the pooled connection for addr, lazily dialing it if this is
+// the first request for that address. The caller must call Release(addr)
+// once it is done using the connection, so EvictIdle can reclaim it if it
+// goes unused for too long.
+func (p *connPool) Get(addr string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	if pc, ok := p.conns[addr]; ok {
+		pc.refCount++
+		pc.idleSince = time.Time{}
+		conn := pc.conn
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+
+	// Dial outside the lock: dialing doesn't block on I/O (grpc.Dial/DialContext
+	// without grpc.WithBlock returns immediately), but it may still do
+	// non-trivial work (credential resolution), which shouldn't hold up
+	// unrelated peers.
+	conn, err := p.dial(addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial %q: %w", addr, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Another goroutine may have created (and even started using) an entry
+	// for addr while we were dialing; prefer it and close our redundant dial
+	// rather than letting two connections to the same peer coexist.
+	if pc, ok := p.conns[addr]; ok {
+		pc.refCount++
+		pc.idleSince = time.Time{}
+		conn.Close()
+		return pc.conn, nil
+	}
+
+	p.conns[addr] = &pooledConn{conn: conn, refCount: 1}
+	return conn, nil
+}
+
+// Release decrements addr's reference count. It does not close the
+// connection: idle connections are kept warm until EvictIdle's idle
+// timeout elapses or Close/CloseAll is called explicitly, since RAFT peers
+// are reconnected to frequently.
+func (p *connPool) Release(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc, ok := p.conns[addr]
+	if !ok || pc.refCount == 0 {
+		return
+	}
+	pc.refCount--
+	if pc.refCount == 0 {
+		pc.idleSince = time.Now()
+	}
+}
+
+// Close tears down and evicts the pooled connection for addr, regardless of
+// its reference count. It is a no-op if addr has no pooled connection.
+func (p *connPool) Close(addr string) error {
+	p.mu.Lock()
+	pc, ok := p.conns[addr]
+	if ok {
+		delete(p.conns, addr)
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return pc.conn.Close()
+}
+
+// EvictIdle closes and evicts every pooled connection that has had no
+// outstanding Get/Release pair (refCount == 0) for at least maxIdle,
+// returning the addresses it evicted. Connections still in use (refCount >
+// 0) are never evicted regardless of age.
+func (p *connPool) EvictIdle(maxIdle time.Duration) []string {
+	now := time.Now()
+
+	p.mu.Lock()
+	var toEvict []*pooledConn
+	var evicted []string
+	for addr, pc := range p.conns {
+		if pc.refCount == 0 && !pc.idleSince.IsZero() && now.Sub(pc.idleSince) >= maxIdle {
+			toEvict = append(toEvict, pc)
+			evicted = append(evicted, addr)
+			delete(p.conns, addr)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pc := range toEvict {
+		pc.conn.Close()
+	}
+	return evicted
+}
+
+// State returns the current connectivity.State of the pooled connection to
+// addr, for observability, and false if no connection has been created yet.
+func (p *connPool) State(addr string) (connectivity.State, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc, ok := p.conns[addr]
+	if !ok {
+		return connectivity.Idle, false
+	}
+	return pc.conn.GetState(), true
+}
+
+// has reports whether addr currently has a pooled entry, for tests.
+func (p *connPool) has(addr string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.conns[addr]
+	return ok
+}
+
+// size returns the number of pooled entries, for tests.
+func (p *connPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.conns)
+}
+
+// CloseAll tears down every pooled connection, e.g. on Client.CloseAll.
+func (p *connPool) CloseAll() error {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = make(map[string]*pooledConn)
+	p.mu.Unlock()
+
+	var firstErr error
+	for addr, pc := range conns {
+		if err := pc.conn.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("close %q: %w", addr, err)
+		}
+	}
+	return firstErr
+}
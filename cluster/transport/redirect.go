@@ -0,0 +1,163 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxLeaderRedirects bounds how many times a single Apply/Query/Join/Remove
+// call will follow a NotLeader redirect before giving up, preventing an
+// infinite loop if the cluster is in the middle of a contested election.
+const maxLeaderRedirects = 2
+
+// leaderHintMetadataKey is the errdetails.ErrorInfo metadata key used to
+// carry the current leader's RAFT address on a NotLeader status.
+const leaderHintMetadataKey = "leader_addr"
+
+// NewNotLeaderError builds the gRPC status a ClusterService handler should
+// return when it isn't the RAFT leader. leaderAddr, if known, is attached to
+// the status details as errdetails.ErrorInfo metadata so the caller can
+// redirect without a separate discovery round-trip; pass "" if the leader is
+// currently unknown. errdetails.ErrorInfo (not a bespoke message type) is
+// used deliberately: it's a standard, already gRPC-wire-marshalable proto
+// message, so the leader hint actually travels on the wire instead of only
+// existing as an in-process convenience value.
+func NewNotLeaderError(leaderAddr string) error {
+	st := status.New(codes.FailedPrecondition, "not leader")
+	if leaderAddr == "" {
+		return st.Err()
+	}
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   "NOT_LEADER",
+		Metadata: map[string]string{leaderHintMetadataKey: leaderAddr},
+	})
+	if err != nil {
+		// Attaching details should never fail for a well-formed ErrorInfo;
+		// fall back to the hint-less status rather than losing the error.
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// leaderHintFromError extracts the leader address carried by a NotLeader
+// status's errdetails.ErrorInfo, returning ok=false if err isn't a NotLeader
+// status or carries no hint.
+func leaderHintFromError(err error) (leaderAddr string, ok bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.FailedPrecondition {
+		return "", false
+	}
+
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Reason != "NOT_LEADER" {
+			continue
+		}
+		addr := info.Metadata[leaderHintMetadataKey]
+		if addr == "" {
+			continue
+		}
+		return addr, true
+	}
+	return "", false
+}
+
+// withLeaderRedirect calls fn against the cached leader address, if any,
+// falling back to peerAddress otherwise. If fn fails with a NotLeader status
+// carrying a leader hint, it caches the hint and retries against the hinted
+// leader, up to maxLeaderRedirects times.
+//
+// If fn fails for any other reason while a cached leader was used, the
+// cache is presumed stale (e.g. the leader crashed or was network-
+// partitioned) rather than simply wrong: it is invalidated, and fn is
+// retried exactly once against peerAddress, the address the caller actually
+// supplied. Without this, a dead cached leader wedges the Client forever,
+// since only a NotLeader status ever clears or updates the cache and a
+// genuinely unreachable node will never return one.
+//
+// fn's error is returned as-is once the redirect/fallback budget is
+// exhausted. rpcName labels the retries_total metric, when metrics are
+// enabled.
+func withLeaderRedirect(cl *Client, rpcName, peerAddress string, fn func(addr string) error) error {
+	addr := cl.cachedLeaderOr(peerAddress)
+	usingCachedLeader := addr != peerAddress
+	fellBack := false
+
+	for attempt := 0; ; attempt++ {
+		err := fn(addr)
+		if err == nil {
+			return nil
+		}
+
+		if leaderAddr, ok := leaderHintFromError(err); ok {
+			if attempt >= maxLeaderRedirects {
+				return err
+			}
+			if cl.metrics != nil {
+				cl.metrics.retriesTotal.WithLabelValues(rpcName).Inc()
+			}
+			cl.setCachedLeader(leaderAddr)
+			addr = leaderAddr
+			usingCachedLeader = true
+			continue
+		}
+
+		if usingCachedLeader && !fellBack {
+			cl.clearCachedLeader(addr)
+			addr = peerAddress
+			usingCachedLeader = false
+			fellBack = true
+			continue
+		}
+
+		return err
+	}
+}
+
+// setCachedLeader records addr as the last-known leader so future calls can
+// skip straight to it instead of repeating the discovery/redirect hop.
+func (cl *Client) setCachedLeader(addr string) {
+	cl.leaderMu.Lock()
+	defer cl.leaderMu.Unlock()
+	if cl.metrics != nil && cl.cachedLeader != addr {
+		cl.metrics.leaderChanges.WithLabelValues().Inc()
+	}
+	cl.cachedLeader = addr
+}
+
+// clearCachedLeader drops the cached leader if it still equals addr. It is
+// called once addr has turned out to be unreachable for a reason other than
+// a NotLeader redirect, so the next call falls back to rediscovering the
+// leader from the caller-supplied address instead of retrying a dead node
+// forever.
+func (cl *Client) clearCachedLeader(addr string) {
+	cl.leaderMu.Lock()
+	defer cl.leaderMu.Unlock()
+	if cl.cachedLeader == addr {
+		cl.cachedLeader = ""
+	}
+}
+
+// cachedLeaderOr returns the last-known leader address, falling back to
+// fallback if no leader has been observed yet.
+func (cl *Client) cachedLeaderOr(fallback string) string {
+	cl.leaderMu.RLock()
+	defer cl.leaderMu.RUnlock()
+	if cl.cachedLeader != "" {
+		return cl.cachedLeader
+	}
+	return fallback
+}
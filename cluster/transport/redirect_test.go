@@ -0,0 +1,121 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNotLeaderErrorRoundTrip(t *testing.T) {
+	err := NewNotLeaderError("10.0.0.2:8300")
+
+	addr, ok := leaderHintFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.2:8300", addr)
+}
+
+func TestNewNotLeaderErrorWithoutHint(t *testing.T) {
+	err := NewNotLeaderError("")
+
+	_, ok := leaderHintFromError(err)
+	assert.False(t, ok)
+}
+
+func TestLeaderHintFromErrorRejectsUnrelatedErrors(t *testing.T) {
+	_, ok := leaderHintFromError(errors.New("boom"))
+	assert.False(t, ok)
+}
+
+func TestWithLeaderRedirectFollowsHintAndCachesLeader(t *testing.T) {
+	cl := NewClient(NewRPCResolver(false, 8300))
+
+	var addrsSeen []string
+	err := withLeaderRedirect(cl, "Apply", "follower:8300", func(addr string) error {
+		addrsSeen = append(addrsSeen, addr)
+		if addr == "follower:8300" {
+			return NewNotLeaderError("leader:8300")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"follower:8300", "leader:8300"}, addrsSeen)
+	assert.Equal(t, "leader:8300", cl.cachedLeaderOr(""))
+}
+
+func TestWithLeaderRedirectFallsBackWhenCachedLeaderUnreachable(t *testing.T) {
+	cl := NewClient(NewRPCResolver(false, 8300))
+	cl.setCachedLeader("dead-leader:8300")
+
+	var addrsSeen []string
+	err := withLeaderRedirect(cl, "Apply", "follower:8300", func(addr string) error {
+		addrsSeen = append(addrsSeen, addr)
+		if addr == "dead-leader:8300" {
+			return errors.New("rpc error: code = Unavailable desc = connection refused")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dead-leader:8300", "follower:8300"}, addrsSeen)
+	// The stale cache entry must be gone, not just bypassed for this call,
+	// or every subsequent call stays wedged on the dead node too.
+	assert.Equal(t, "", cl.cachedLeaderOr(""))
+}
+
+func TestWithLeaderRedirectFallsBackOnlyOnce(t *testing.T) {
+	cl := NewClient(NewRPCResolver(false, 8300))
+	cl.setCachedLeader("dead-leader:8300")
+
+	attempts := 0
+	err := withLeaderRedirect(cl, "Apply", "follower:8300", func(addr string) error {
+		attempts++
+		return errors.New("rpc error: code = Unavailable desc = connection refused")
+	})
+
+	require.Error(t, err)
+	// One try against the cached leader, one fallback try against
+	// peerAddress, then give up: the fallback must not loop.
+	assert.Equal(t, 2, attempts)
+}
+
+func TestWithLeaderRedirectDoesNotFallBackWithoutACachedLeader(t *testing.T) {
+	cl := NewClient(NewRPCResolver(false, 8300))
+
+	attempts := 0
+	err := withLeaderRedirect(cl, "Apply", "follower:8300", func(addr string) error {
+		attempts++
+		return errors.New("rpc error: code = Unavailable desc = connection refused")
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWithLeaderRedirectIsBounded(t *testing.T) {
+	cl := NewClient(NewRPCResolver(false, 8300))
+
+	attempts := 0
+	err := withLeaderRedirect(cl, "Apply", "node-0:8300", func(addr string) error {
+		attempts++
+		// Every node, node-1 included, names node-1 as the leader, so
+		// without a bound this would redirect forever.
+		return NewNotLeaderError("node-1:8300")
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, maxLeaderRedirects+1, attempts)
+}
@@ -0,0 +1,106 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PeerMetadata is the address information a node advertises about itself
+// through the Raft log, keyed by its Raft address. It is the decoded,
+// in-process form of an AdvertisePeer command once the FSM has applied it —
+// PeerRegistry itself has no opinion on how that command is encoded on the
+// wire (e.g. as the SubCommand payload of the existing, already proto-real
+// cmd.ApplyRequest); that encoding belongs to the Raft store/FSM package
+// that owns the log, not to this transport-level read model.
+type PeerMetadata struct {
+	RaftAddr string
+	RPCAddr  string
+	APIAddr  string
+	Version  string
+}
+
+// PeerRegistry is a Raft-log-backed replacement for the "same port"/"+1 for
+// local" port-arithmetic previously used to derive a peer's RPC address from
+// its Raft address. Every node's metadata is replicated to every other node
+// as an AdvertisePeer FSM command; PeerRegistry holds the locally applied
+// view of that state and implements rpcAddressResolver by reading from it,
+// so it can be passed directly to NewClient/NewClientWithOptions.
+//
+// PeerRegistry is a read model only: nothing in this package calls Apply.
+// Client.Join/Client.Notify still just forward the caller's request over
+// gRPC; publishing the local node's own {raftAddr, rpcAddr, apiAddr,
+// version} as an AdvertisePeer command on Join/Notify, and decoding that
+// command back into a PeerMetadata to call Apply with, is the write side of
+// this feature and belongs to the Raft store/FSM package that owns the log
+// — it is out of scope for cluster/transport, which has no access to the
+// FSM's apply path.
+type PeerRegistry struct {
+	mu    sync.RWMutex
+	peers map[string]PeerMetadata
+}
+
+// NewPeerRegistry returns an empty PeerRegistry. The FSM calls Apply with
+// the decoded PeerMetadata as AdvertisePeer commands are committed through
+// Raft.
+func NewPeerRegistry() *PeerRegistry {
+	return &PeerRegistry{peers: make(map[string]PeerMetadata)}
+}
+
+// Apply records or updates the advertised metadata for meta.RaftAddr. It is
+// called by the FSM once it has decoded an AdvertisePeer command applied
+// from the Raft log, and is safe to call from multiple FSM apply goroutines.
+func (r *PeerRegistry) Apply(meta PeerMetadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers[meta.RaftAddr] = meta
+}
+
+// Remove drops any advertised metadata for raftAddr, e.g. once a peer has
+// been removed from the cluster.
+func (r *PeerRegistry) Remove(raftAddr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.peers, raftAddr)
+}
+
+// Get returns the advertised metadata for raftAddr, if any.
+func (r *PeerRegistry) Get(raftAddr string) (PeerMetadata, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.peers[raftAddr]
+	return m, ok
+}
+
+// All returns the advertised metadata for every known peer, e.g. for
+// snapshotting the registry alongside the rest of the FSM state.
+func (r *PeerRegistry) All() []PeerMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]PeerMetadata, 0, len(r.peers))
+	for _, m := range r.peers {
+		out = append(out, m)
+	}
+	return out
+}
+
+// Address implements rpcAddressResolver by looking up the RPC address a peer
+// advertised for itself, instead of deriving it from raftAddr by port
+// arithmetic.
+func (r *PeerRegistry) Address(raftAddr string) (string, error) {
+	m, ok := r.Get(raftAddr)
+	if !ok {
+		return "", fmt.Errorf("no advertised RPC address for raft peer %q", raftAddr)
+	}
+	return m.RPCAddr, nil
+}
@@ -0,0 +1,72 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerRegistryApplyAndGet(t *testing.T) {
+	r := NewPeerRegistry()
+
+	r.Apply(PeerMetadata{RaftAddr: "node-1:8300", RPCAddr: "node-1:9300", Version: "1.0.0"})
+
+	meta, ok := r.Get("node-1:8300")
+	require.True(t, ok)
+	assert.Equal(t, "node-1:9300", meta.RPCAddr)
+	assert.Equal(t, "1.0.0", meta.Version)
+}
+
+func TestPeerRegistryApplyOverwritesExistingMetadata(t *testing.T) {
+	r := NewPeerRegistry()
+	r.Apply(PeerMetadata{RaftAddr: "node-1:8300", RPCAddr: "node-1:9300"})
+	r.Apply(PeerMetadata{RaftAddr: "node-1:8300", RPCAddr: "node-1:9999"})
+
+	meta, ok := r.Get("node-1:8300")
+	require.True(t, ok)
+	assert.Equal(t, "node-1:9999", meta.RPCAddr)
+}
+
+func TestPeerRegistryAddressUnknownPeer(t *testing.T) {
+	r := NewPeerRegistry()
+	_, err := r.Address("does-not-exist:8300")
+	assert.Error(t, err)
+}
+
+func TestPeerRegistryAddressMatchesAdvertisedRPCAddr(t *testing.T) {
+	r := NewPeerRegistry()
+	r.Apply(PeerMetadata{RaftAddr: "node-1:8300", RPCAddr: "node-1:9300"})
+
+	addr, err := r.Address("node-1:8300")
+	require.NoError(t, err)
+	assert.Equal(t, "node-1:9300", addr)
+}
+
+func TestPeerRegistryRemove(t *testing.T) {
+	r := NewPeerRegistry()
+	r.Apply(PeerMetadata{RaftAddr: "node-1:8300", RPCAddr: "node-1:9300"})
+	r.Remove("node-1:8300")
+
+	_, ok := r.Get("node-1:8300")
+	assert.False(t, ok)
+}
+
+func TestPeerRegistryAll(t *testing.T) {
+	r := NewPeerRegistry()
+	r.Apply(PeerMetadata{RaftAddr: "node-1:8300", RPCAddr: "node-1:9300"})
+	r.Apply(PeerMetadata{RaftAddr: "node-2:8300", RPCAddr: "node-2:9300"})
+
+	assert.Len(t, r.All(), 2)
+}
@@ -0,0 +1,92 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// ServerTLSConfig configures mutual TLS for the ClusterService gRPC server:
+// the mirror image of TLSCredentialsConfig on the dialing side.
+type ServerTLSConfig struct {
+	// CertFile and KeyFile identify this node to connecting peers.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the CA bundle used to verify a connecting peer's
+	// client certificate. RequireAndVerifyClientCert is always set: a
+	// ClusterService server with mTLS enabled rejects unauthenticated
+	// peers rather than merely encrypting the channel.
+	ClientCAFile string
+}
+
+// NewServerTLSCredentials loads cfg's certificate and CA bundle from disk
+// and returns server-side transport credentials that require and verify a
+// client certificate on every incoming connection.
+func NewServerTLSCredentials(cfg ServerTLSConfig) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+
+	caPool := x509.NewCertPool()
+	caPEM, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client ca bundle: %w", err)
+	}
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("parse client ca bundle %q: no certificates found", cfg.ClientCAFile)
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}), nil
+}
+
+// NewServer builds the *grpc.Server the ClusterService is registered
+// against, with creds enforcing mTLS and every unary RPC routed through
+// PeerAuthUnaryInterceptor so Apply/Query/JoinPeer/RemovePeer handlers can
+// read the caller's verified PeerIdentity via PeerIdentityFromContext and
+// authorize it with authz before the request reaches them. extraOpts are
+// appended after the mTLS/authorization wiring so callers can still add
+// e.g. keepalive or message-size options.
+//
+// PeerAuthUnaryInterceptor rejects any request without a verified TLS peer
+// certificate, so creds must actually perform mTLS (e.g. one built with
+// NewServerTLSCredentials): pairing NewServer with insecure.NewCredentials()
+// would reject every RPC. Use NewInsecureServer for a cluster that isn't
+// running mTLS yet.
+func NewServer(creds credentials.TransportCredentials, authz PeerAuthorizer, extraOpts ...grpc.ServerOption) *grpc.Server {
+	opts := append([]grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(PeerAuthUnaryInterceptor(authz)),
+	}, extraOpts...)
+	return grpc.NewServer(opts...)
+}
+
+// NewInsecureServer builds a ClusterService gRPC server with plaintext
+// connections and no peer authorization, mirroring InsecureCredentialsProvider
+// on the dialing side. It exists so that a gradual mTLS rollout, or a
+// local/test cluster that never enables mTLS at all, has an explicit,
+// intentional way to opt out of authentication instead of pairing NewServer
+// with insecure credentials and having every RPC rejected by
+// PeerAuthUnaryInterceptor's "no verified peer certificate" check.
+func NewInsecureServer(extraOpts ...grpc.ServerOption) *grpc.Server {
+	return grpc.NewServer(extraOpts...)
+}
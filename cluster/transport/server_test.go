@@ -0,0 +1,94 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServerTLSCredentialsMissingCertFile(t *testing.T) {
+	dir := t.TempDir()
+	_, caFile := writeTestCA(t, dir)
+
+	_, err := NewServerTLSCredentials(ServerTLSConfig{
+		CertFile:     filepath.Join(dir, "does-not-exist.crt"),
+		KeyFile:      filepath.Join(dir, "does-not-exist.key"),
+		ClientCAFile: caFile,
+	})
+	assert.ErrorContains(t, err, "load key pair")
+}
+
+func TestNewServerTLSCredentialsMissingCAFile(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	_, err := NewServerTLSCredentials(ServerTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: filepath.Join(dir, "does-not-exist.pem"),
+	})
+	assert.ErrorContains(t, err, "read client ca bundle")
+}
+
+func TestNewServerTLSCredentialsInvalidCABundle(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	garbageCA := filepath.Join(dir, "garbage.pem")
+	writeFile(t, garbageCA, []byte("not a certificate"))
+
+	_, err := NewServerTLSCredentials(ServerTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: garbageCA,
+	})
+	assert.ErrorContains(t, err, "no certificates found")
+}
+
+func TestNewServerTLSCredentialsSucceeds(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	_, caFile := writeTestCA(t, dir)
+
+	creds, err := NewServerTLSCredentials(ServerTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tls", creds.Info().SecurityProtocol)
+}
+
+func TestNewServerBuildsNonNilServer(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	_, caFile := writeTestCA(t, dir)
+	creds, err := NewServerTLSCredentials(ServerTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+	})
+	require.NoError(t, err)
+
+	srv := NewServer(creds, stubAuthorizer{})
+	require.NotNil(t, srv)
+	srv.Stop()
+}
+
+func TestNewInsecureServerBuildsNonNilServer(t *testing.T) {
+	srv := NewInsecureServer()
+	require.NotNil(t, srv)
+	srv.Stop()
+}
@@ -0,0 +1,124 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// DefaultSnapshotChunkSize is the payload size of each SnapshotChunk yielded
+// by readSnapshotChunks when chunkSize is zero or negative.
+const DefaultSnapshotChunkSize = 1 << 20 // 1 MiB
+
+var crc32CTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SnapshotOption configures a single InstallSnapshot call.
+type SnapshotOption func(*snapshotConfig)
+
+type snapshotConfig struct {
+	chunkSize   int
+	startOffset int64
+}
+
+// WithSnapshotChunkSize sets the chunk size in place of
+// DefaultSnapshotChunkSize. InstallSnapshot accepts this option but returns
+// ErrSnapshotTransferNotImplemented without applying it.
+func WithSnapshotChunkSize(n int) SnapshotOption {
+	return func(c *snapshotConfig) { c.chunkSize = n }
+}
+
+// WithSnapshotStartOffset sets the byte offset off at which a previously
+// interrupted transfer resumes. InstallSnapshot accepts this option but
+// returns ErrSnapshotTransferNotImplemented without reading or seeking r.
+func WithSnapshotStartOffset(off int64) SnapshotOption {
+	return func(c *snapshotConfig) { c.startOffset = off }
+}
+
+// SnapshotChunk is one piece of a snapshot payload read by
+// readSnapshotChunks: its byte offset within the full payload, the chunk
+// itself, and a CRC32C checksum of it.
+type SnapshotChunk struct {
+	Offset uint64
+	Data   []byte
+	Crc32C uint32
+}
+
+// readSnapshotChunks splits r into chunks of at most chunkSize bytes,
+// starting at startOffset, computing each chunk's CRC32C, and invoking yield
+// for every chunk in order until r is exhausted. It exists so the chunking/
+// checksum/resume logic is ready and independently tested ahead of whatever
+// eventually carries a chunk to the leader.
+func readSnapshotChunks(r io.Reader, chunkSize int, startOffset int64, yield func(SnapshotChunk) error) error {
+	offset := startOffset
+	if startOffset > 0 {
+		seeker, ok := r.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("install snapshot: start offset %d requires an io.Seeker", startOffset)
+		}
+		if _, err := seeker.Seek(startOffset, io.SeekStart); err != nil {
+			return fmt.Errorf("install snapshot: seek to offset %d: %w", startOffset, err)
+		}
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultSnapshotChunkSize
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk := SnapshotChunk{
+				Offset: uint64(offset),
+				Data:   append([]byte(nil), buf[:n]...),
+				Crc32C: crc32.Checksum(buf[:n], crc32CTable),
+			}
+			if err := yield(chunk); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("read snapshot payload at offset %d: %w", offset, readErr)
+		}
+	}
+}
+
+// ErrSnapshotTransferNotImplemented is returned by InstallSnapshot until a
+// real transfer path exists.
+var ErrSnapshotTransferNotImplemented = errors.New("cluster/transport: snapshot transfer not implemented")
+
+// InstallSnapshot is not yet implemented.
+//
+// A prior version of this function sent each chunk through Client.Apply,
+// i.e. committed the snapshot to the replicated Raft log one chunk at a
+// time via full consensus (leader proposal, majority replication, fsync,
+// FSM apply) before reading the next one. That's backwards: snapshot
+// transfer exists precisely so a catching-up follower can skip the log
+// entries compaction already discarded, instead of replaying the whole
+// snapshot through the log a second time — and it turns what should be a
+// single bulk copy into one sequential consensus round-trip per chunk.
+//
+// A correct implementation needs a side channel that bypasses the
+// replicated log entirely — most likely a dedicated ClusterService
+// streaming RPC — which means a .proto change and regenerated client/server
+// stubs that this package can't produce on its own (no protoc in this
+// build). readSnapshotChunks already has the chunking/CRC32C/resume logic
+// such an RPC would need, ready to wire up once that dependency lands.
+func (cl *Client) InstallSnapshot(leaderAddr string, r io.Reader, opts ...SnapshotOption) error {
+	return ErrSnapshotTransferNotImplemented
+}
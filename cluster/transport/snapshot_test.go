@@ -0,0 +1,109 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package transport
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallSnapshotNotImplemented(t *testing.T) {
+	cl := NewClient(NewRPCResolver(false, 8300))
+	err := cl.InstallSnapshot("leader:8300", bytes.NewReader(nil))
+	assert.ErrorIs(t, err, ErrSnapshotTransferNotImplemented)
+}
+
+func TestReadSnapshotChunksSplitsOnChunkSize(t *testing.T) {
+	payload := []byte("abcdefghij") // 10 bytes
+	var chunks []SnapshotChunk
+	err := readSnapshotChunks(bytes.NewReader(payload), 4, 0, func(c SnapshotChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, []byte("abcd"), chunks[0].Data)
+	assert.EqualValues(t, 0, chunks[0].Offset)
+	assert.Equal(t, []byte("efgh"), chunks[1].Data)
+	assert.EqualValues(t, 4, chunks[1].Offset)
+	assert.Equal(t, []byte("ij"), chunks[2].Data)
+	assert.EqualValues(t, 8, chunks[2].Offset)
+}
+
+func TestReadSnapshotChunksComputesCrc32C(t *testing.T) {
+	payload := []byte("hello world")
+	var chunks []SnapshotChunk
+	err := readSnapshotChunks(bytes.NewReader(payload), 1<<20, 0, func(c SnapshotChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+
+	want := crc32.Checksum(payload, crc32.MakeTable(crc32.Castagnoli))
+	assert.Equal(t, want, chunks[0].Crc32C)
+}
+
+func TestReadSnapshotChunksResumesAtStartOffset(t *testing.T) {
+	payload := []byte("0123456789")
+	r := bytes.NewReader(payload)
+
+	var chunks []SnapshotChunk
+	err := readSnapshotChunks(r, 4, 4, func(c SnapshotChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 2)
+	assert.EqualValues(t, 4, chunks[0].Offset)
+	assert.Equal(t, []byte("4567"), chunks[0].Data)
+	assert.EqualValues(t, 8, chunks[1].Offset)
+	assert.Equal(t, []byte("89"), chunks[1].Data)
+}
+
+// readOnly wraps an io.Reader without exposing any Seek method it might
+// have, simulating a plain, non-seekable io.Reader.
+type readOnly struct{ r io.Reader }
+
+func (ro readOnly) Read(p []byte) (int, error) { return ro.r.Read(p) }
+
+func TestReadSnapshotChunksRejectsStartOffsetWithoutSeeker(t *testing.T) {
+	nonSeeker := readOnly{strings.NewReader("0123456789")}
+
+	err := readSnapshotChunks(nonSeeker, 4, 4, func(SnapshotChunk) error {
+		t.Fatal("yield should not be called")
+		return nil
+	})
+	assert.Error(t, err)
+}
+
+func TestReadSnapshotChunksPropagatesYieldError(t *testing.T) {
+	payload := []byte("abcdefgh")
+	calls := 0
+	err := readSnapshotChunks(bytes.NewReader(payload), 4, 0, func(c SnapshotChunk) error {
+		calls++
+		if calls == 2 {
+			return assert.AnError
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 2, calls)
+}